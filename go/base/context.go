@@ -26,6 +26,7 @@ const (
 	TableStatusRowsEstimate RowsEstimateMethod = "TableStatusRowsEstimate"
 	ExplainRowsEstimate                        = "ExplainRowsEstimate"
 	CountRowsEstimate                          = "CountRowsEstimate"
+	SampledRowsEstimate                        = "SampledRowsEstimate"
 )
 
 type CutOver int
@@ -58,6 +59,8 @@ type MigrationContext struct {
 	CliUser     string
 	CliPassword string
 
+	ServeSocketFile string
+
 	ChunkSize                           int64
 	MaxLagMillisecondsThrottleThreshold int64
 	ReplictionLagQuery                  string
@@ -68,6 +71,9 @@ type MigrationContext struct {
 	PostponeSwapTablesFlagFile          string
 	SwapTablesTimeoutSeconds            int64
 
+	throttleControlReplicaKeysMutex *sync.Mutex
+	maxLoadMutex                    *sync.Mutex
+
 	Noop                    bool
 	TestOnReplica           bool
 	OkToDropTable           bool
@@ -78,6 +84,7 @@ type MigrationContext struct {
 	TableEngine               string
 	RowsEstimate              int64
 	UsedRowsEstimateMethod    RowsEstimateMethod
+	RowsEstimator             RowsEstimator
 	OriginalBinlogFormat      string
 	OriginalBinlogRowImage    string
 	InspectorConnectionConfig *mysql.ConnectionConfig
@@ -142,6 +149,8 @@ func newMigrationContext() *MigrationContext {
 		ThrottleControlReplicaKeys:          mysql.NewInstanceKeyMap(),
 		configMutex:                         &sync.Mutex{},
 		pointOfInterestTimeMutex:            &sync.Mutex{},
+		throttleControlReplicaKeysMutex:     &sync.Mutex{},
+		maxLoadMutex:                        &sync.Mutex{},
 	}
 }
 
@@ -171,6 +180,17 @@ func (this *MigrationContext) GetVoluntaryLockName() string {
 	return fmt.Sprintf("%s.%s.lock", this.DatabaseName, this.OriginalTableName)
 }
 
+// GetServeSocketFile returns the path of the Unix socket used to interactively
+// control this migration. When not explicitly set via --serve-socket-file, a
+// name derived from the database and table is used, so that concurrent
+// migrations running on the same host don't collide.
+func (this *MigrationContext) GetServeSocketFile() string {
+	if this.ServeSocketFile != "" {
+		return this.ServeSocketFile
+	}
+	return fmt.Sprintf("/tmp/gh-ost.%s.%s.sock", this.DatabaseName, this.OriginalTableName)
+}
+
 // RequiresBinlogFormatChange is `true` when the original binlog format isn't `ROW`
 func (this *MigrationContext) RequiresBinlogFormatChange() bool {
 	return this.OriginalBinlogFormat != "ROW"
@@ -255,30 +275,126 @@ func (this *MigrationContext) IsThrottled() (bool, string) {
 	return this.isThrottled, this.throttleReason
 }
 
-// ReadMaxLoad parses the `--max-load` flag, which is in multiple key-value format,
-// such as: 'Threads_running=100,Threads_connected=500'
-func (this *MigrationContext) ReadMaxLoad(maxLoadList string) error {
+// parseMaxLoad parses the multiple key-value format shared by the `--max-load`
+// flag and the `max-load` control socket command, such as:
+// 'Threads_running=100,Threads_connected=500'
+func parseMaxLoad(maxLoadList string) (map[string]int64, error) {
+	maxLoadMap := make(map[string]int64)
 	if maxLoadList == "" {
-		return nil
+		return maxLoadMap, nil
 	}
 	maxLoadConditions := strings.Split(maxLoadList, ",")
 	for _, maxLoadCondition := range maxLoadConditions {
 		maxLoadTokens := strings.Split(maxLoadCondition, "=")
 		if len(maxLoadTokens) != 2 {
-			return fmt.Errorf("Error parsing max-load condition: %s", maxLoadCondition)
+			return nil, fmt.Errorf("Error parsing max-load condition: %s", maxLoadCondition)
 		}
 		if maxLoadTokens[0] == "" {
-			return fmt.Errorf("Error parsing status variable in max-load condition: %s", maxLoadCondition)
+			return nil, fmt.Errorf("Error parsing status variable in max-load condition: %s", maxLoadCondition)
 		}
 		if n, err := strconv.ParseInt(maxLoadTokens[1], 10, 0); err != nil {
-			return fmt.Errorf("Error parsing numeric value in max-load condition: %s", maxLoadCondition)
+			return nil, fmt.Errorf("Error parsing numeric value in max-load condition: %s", maxLoadCondition)
 		} else {
-			this.MaxLoad[maxLoadTokens[0]] = n
+			maxLoadMap[maxLoadTokens[0]] = n
 		}
 	}
+	return maxLoadMap, nil
+}
+
+// ReadMaxLoad parses the `--max-load` flag, which is in multiple key-value format,
+// such as: 'Threads_running=100,Threads_connected=500'
+func (this *MigrationContext) ReadMaxLoad(maxLoadList string) error {
+	maxLoadMap, err := parseMaxLoad(maxLoadList)
+	if err != nil {
+		return err
+	}
+	for variableName, value := range maxLoadMap {
+		this.MaxLoad[variableName] = value
+	}
+	return nil
+}
+
+// SetChunkSize safely updates the number of rows to be handled in each copy iteration.
+// It is called both on startup and, later, by a running migration's control socket.
+func (this *MigrationContext) SetChunkSize(chunkSize int64) {
+	if chunkSize < 100 {
+		chunkSize = 100
+	}
+	if chunkSize > 100000 {
+		chunkSize = 100000
+	}
+	atomic.StoreInt64(&this.ChunkSize, chunkSize)
+}
+
+// GetChunkSize safely returns the current chunk size.
+func (this *MigrationContext) GetChunkSize() int64 {
+	return atomic.LoadInt64(&this.ChunkSize)
+}
+
+// SetMaxLagMillisecondsThrottleThreshold safely updates the replication lag threshold,
+// above which the migration throttles itself.
+func (this *MigrationContext) SetMaxLagMillisecondsThrottleThreshold(maxLagMillisecondsThrottleThreshold int64) {
+	if maxLagMillisecondsThrottleThreshold < 100 {
+		maxLagMillisecondsThrottleThreshold = 100
+	}
+	atomic.StoreInt64(&this.MaxLagMillisecondsThrottleThreshold, maxLagMillisecondsThrottleThreshold)
+}
+
+// GetMaxLagMillisecondsThrottleThreshold safely returns the current replication lag threshold.
+func (this *MigrationContext) GetMaxLagMillisecondsThrottleThreshold() int64 {
+	return atomic.LoadInt64(&this.MaxLagMillisecondsThrottleThreshold)
+}
+
+// SetThrottleControlReplicaKeys safely replaces the list of replicas whose lag
+// throttles the migration, parsing a comma delimited "host:port" list such as
+// the one accepted by the `--throttle-control-replicas` flag.
+func (this *MigrationContext) SetThrottleControlReplicaKeys(throttleControlReplicas string) error {
+	keys := mysql.NewInstanceKeyMap()
+	if err := keys.ReadCommaDelimitedList(throttleControlReplicas); err != nil {
+		return err
+	}
+
+	this.throttleControlReplicaKeysMutex.Lock()
+	defer this.throttleControlReplicaKeysMutex.Unlock()
+	this.ThrottleControlReplicaKeys = keys
 	return nil
 }
 
+// GetThrottleControlReplicaKeys safely returns the current list of throttle control replicas.
+func (this *MigrationContext) GetThrottleControlReplicaKeys() *mysql.InstanceKeyMap {
+	this.throttleControlReplicaKeysMutex.Lock()
+	defer this.throttleControlReplicaKeysMutex.Unlock()
+	return this.ThrottleControlReplicaKeys
+}
+
+// SetMaxLoad safely replaces the max-load thresholds, in the same key-value format
+// accepted by the `--max-load` flag and by ReadMaxLoad.
+func (this *MigrationContext) SetMaxLoad(maxLoadList string) error {
+	maxLoadMap, err := parseMaxLoad(maxLoadList)
+	if err != nil {
+		return err
+	}
+
+	this.maxLoadMutex.Lock()
+	defer this.maxLoadMutex.Unlock()
+	this.MaxLoad = maxLoadMap
+	return nil
+}
+
+// GetMaxLoad safely returns the current max-load thresholds.
+func (this *MigrationContext) GetMaxLoad() map[string]int64 {
+	this.maxLoadMutex.Lock()
+	defer this.maxLoadMutex.Unlock()
+	return this.MaxLoad
+}
+
+// SetRowsEstimator plugs a custom RowsEstimator into the migration, used by
+// the inspector whenever UsedRowsEstimateMethod doesn't match one of the
+// built-in methods (TableStatusRowsEstimate, ExplainRowsEstimate, CountRowsEstimate).
+func (this *MigrationContext) SetRowsEstimator(rowsEstimator RowsEstimator) {
+	this.RowsEstimator = rowsEstimator
+}
+
 // ApplyCredentials sorts out the credentials between the config file and the CLI flags
 func (this *MigrationContext) ApplyCredentials() {
 	this.configMutex.Lock()
@@ -0,0 +1,110 @@
+/*
+   Copyright 2016 GitHub Inc.
+	 See https://github.com/github/gh-ost/blob/master/LICENSE
+*/
+
+package base
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/github/gh-ost/go/sql"
+)
+
+func contextWithRange(minValue, maxValue interface{}) *MigrationContext {
+	context := newMigrationContext()
+	context.DatabaseName = "mydb"
+	context.OriginalTableName = "mytable"
+	context.UniqueKey = &sql.UniqueKey{
+		Name:    "PRIMARY",
+		Columns: *sql.NewColumnList([]string{"id"}),
+	}
+	context.MigrationRangeMinValues = sql.NewColumnValues([]interface{}{minValue})
+	context.MigrationRangeMaxValues = sql.NewColumnValues([]interface{}{maxValue})
+	return context
+}
+
+// TestSampledRowsEstimatorDensity verifies the extrapolation math: 10 equal
+// segments, a probe covering a tenth of each, 5 rows per probe should
+// extrapolate to half the rows across the full [0, 1000) range.
+func TestSampledRowsEstimatorDensity(t *testing.T) {
+	context := contextWithRange(int64(0), int64(1000))
+	estimator := &SampledRowsEstimator{Samples: 10}
+
+	var probesIssued int
+	query := func(query string, args ...interface{}) (int64, error) {
+		probesIssued++
+		return 5, nil
+	}
+
+	rowsEstimate, err := estimator.EstimateRows(context, query)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if probesIssued != 10 {
+		t.Errorf("expected 10 probes, got %d", probesIssued)
+	}
+	if rowsEstimate != 500 {
+		t.Errorf("expected estimate of 500, got %d", rowsEstimate)
+	}
+}
+
+func TestSampledRowsEstimatorZeroWidthRange(t *testing.T) {
+	context := contextWithRange(int64(5), int64(5))
+	estimator := NewSampledRowsEstimator()
+
+	query := func(query string, args ...interface{}) (int64, error) {
+		t.Fatalf("query should not be issued for a zero-width range")
+		return 0, nil
+	}
+
+	rowsEstimate, err := estimator.EstimateRows(context, query)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if rowsEstimate != 0 {
+		t.Errorf("expected estimate of 0, got %d", rowsEstimate)
+	}
+}
+
+func TestSampledRowsEstimatorRequiresUniqueKey(t *testing.T) {
+	context := contextWithRange(int64(0), int64(1000))
+	context.UniqueKey = nil
+	estimator := NewSampledRowsEstimator()
+
+	query := func(query string, args ...interface{}) (int64, error) {
+		return 0, nil
+	}
+
+	if _, err := estimator.EstimateRows(context, query); err == nil {
+		t.Fatalf("expected an error when no unique key is set")
+	}
+}
+
+func TestSampledRowsEstimatorRequiresNumericKey(t *testing.T) {
+	context := contextWithRange("a", "z")
+	estimator := NewSampledRowsEstimator()
+
+	query := func(query string, args ...interface{}) (int64, error) {
+		return 0, nil
+	}
+
+	if _, err := estimator.EstimateRows(context, query); err == nil {
+		t.Fatalf("expected an error for a non-numeric unique key")
+	}
+}
+
+func TestSampledRowsEstimatorPropagatesQueryError(t *testing.T) {
+	context := contextWithRange(int64(0), int64(1000))
+	estimator := NewSampledRowsEstimator()
+
+	expectedErr := fmt.Errorf("connection lost")
+	query := func(query string, args ...interface{}) (int64, error) {
+		return 0, expectedErr
+	}
+
+	if _, err := estimator.EstimateRows(context, query); err != expectedErr {
+		t.Fatalf("expected query error to propagate, got: %v", err)
+	}
+}
@@ -0,0 +1,125 @@
+/*
+   Copyright 2016 GitHub Inc.
+	 See https://github.com/github/gh-ost/blob/master/LICENSE
+*/
+
+package logic
+
+import (
+	gosql "database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/github/gh-ost/go/base"
+)
+
+// Inspector reads metadata about the migrated table, including its estimated row count.
+type Inspector struct {
+	db               *gosql.DB
+	migrationContext *base.MigrationContext
+}
+
+func NewInspector(db *gosql.DB, migrationContext *base.MigrationContext) *Inspector {
+	return &Inspector{
+		db:               db,
+		migrationContext: migrationContext,
+	}
+}
+
+// estimateTableRowsCount populates migrationContext.RowsEstimate, dispatching
+// on migrationContext.UsedRowsEstimateMethod. Methods beyond the three
+// built-in ones are expected to be backed by a base.RowsEstimator plugged
+// into the context via SetRowsEstimator.
+func (this *Inspector) estimateTableRowsCount() (err error) {
+	context := this.migrationContext
+
+	switch context.UsedRowsEstimateMethod {
+	case base.TableStatusRowsEstimate:
+		context.RowsEstimate, err = this.estimateTableRowsViaTableStatus()
+	case base.ExplainRowsEstimate:
+		context.RowsEstimate, err = this.estimateTableRowsViaExplain()
+	case base.CountRowsEstimate:
+		context.RowsEstimate, err = this.queryRowsCount(
+			fmt.Sprintf("select count(*) from `%s`.`%s`", context.DatabaseName, context.OriginalTableName),
+		)
+	case base.SampledRowsEstimate:
+		if context.RowsEstimator == nil {
+			context.SetRowsEstimator(base.NewSampledRowsEstimator())
+		}
+		context.RowsEstimate, err = context.RowsEstimator.EstimateRows(context, this.queryRowsCount)
+	default:
+		if context.RowsEstimator == nil {
+			return fmt.Errorf("no RowsEstimator configured for rows estimate method %s", context.UsedRowsEstimateMethod)
+		}
+		context.RowsEstimate, err = context.RowsEstimator.EstimateRows(context, this.queryRowsCount)
+	}
+	return err
+}
+
+func (this *Inspector) estimateTableRowsViaTableStatus() (int64, error) {
+	context := this.migrationContext
+	query := fmt.Sprintf("show table status from `%s` like ?", context.DatabaseName)
+	return this.rowsFromColumn(query, "Rows", context.OriginalTableName)
+}
+
+func (this *Inspector) estimateTableRowsViaExplain() (int64, error) {
+	context := this.migrationContext
+	query := fmt.Sprintf("explain select * from `%s`.`%s`", context.DatabaseName, context.OriginalTableName)
+	return this.rowsFromColumn(query, "rows")
+}
+
+// rowsFromColumn runs a query whose result set contains a numeric column
+// named `columnName`, and returns the value of that column on the last row
+// read. It's used by the estimate methods (SHOW TABLE STATUS, EXPLAIN) that
+// return a wider result set than a single scalar.
+func (this *Inspector) rowsFromColumn(query string, columnName string, args ...interface{}) (int64, error) {
+	rows, err := this.db.Query(query, args...)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return 0, err
+	}
+	columnIndex := -1
+	for i, column := range columns {
+		if strings.EqualFold(column, columnName) {
+			columnIndex = i
+		}
+	}
+	if columnIndex < 0 {
+		return 0, fmt.Errorf("could not find `%s` column in result set of: %s", columnName, query)
+	}
+
+	dest := make([]gosql.RawBytes, len(columns))
+	scanDest := make([]interface{}, len(columns))
+	for i := range dest {
+		scanDest[i] = &dest[i]
+	}
+
+	var rowsEstimate int64
+	for rows.Next() {
+		if err := rows.Scan(scanDest...); err != nil {
+			return 0, err
+		}
+		rowsEstimate, err = strconv.ParseInt(string(dest[columnIndex]), 10, 64)
+		if err != nil {
+			return 0, err
+		}
+	}
+	return rowsEstimate, rows.Err()
+}
+
+// queryRowsCount runs a query that returns a single numeric row, such as a
+// `SELECT COUNT(*)`. It also serves as the base.QueryFunc handed to a
+// pluggable base.RowsEstimator.
+func (this *Inspector) queryRowsCount(query string, args ...interface{}) (int64, error) {
+	var count int64
+	if err := this.db.QueryRow(query, args...).Scan(&count); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
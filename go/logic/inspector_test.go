@@ -0,0 +1,59 @@
+/*
+   Copyright 2016 GitHub Inc.
+	 See https://github.com/github/gh-ost/blob/master/LICENSE
+*/
+
+package logic
+
+import (
+	"testing"
+
+	"github.com/github/gh-ost/go/base"
+)
+
+func TestEstimateTableRowsCountDefaultsSampledEstimator(t *testing.T) {
+	context := base.GetMigrationContext()
+	context.UsedRowsEstimateMethod = base.SampledRowsEstimate
+	context.RowsEstimator = nil
+	context.MigrationRangeMinValues = nil
+	context.MigrationRangeMaxValues = nil
+
+	inspector := NewInspector(nil, context)
+	if err := inspector.estimateTableRowsCount(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if context.RowsEstimator == nil {
+		t.Fatalf("expected estimateTableRowsCount to default RowsEstimator for SampledRowsEstimate")
+	}
+	if _, ok := context.RowsEstimator.(*base.SampledRowsEstimator); !ok {
+		t.Fatalf("expected a *base.SampledRowsEstimator, got %T", context.RowsEstimator)
+	}
+}
+
+func TestEstimateTableRowsCountKeepsConfiguredEstimator(t *testing.T) {
+	context := base.GetMigrationContext()
+	context.UsedRowsEstimateMethod = base.SampledRowsEstimate
+	context.MigrationRangeMinValues = nil
+	context.MigrationRangeMaxValues = nil
+	custom := &base.SampledRowsEstimator{Samples: 42}
+	context.SetRowsEstimator(custom)
+
+	inspector := NewInspector(nil, context)
+	if err := inspector.estimateTableRowsCount(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if context.RowsEstimator != custom {
+		t.Fatalf("expected a pre-configured RowsEstimator to be left untouched")
+	}
+}
+
+func TestEstimateTableRowsCountUnknownMethod(t *testing.T) {
+	context := base.GetMigrationContext()
+	context.UsedRowsEstimateMethod = base.RowsEstimateMethod("SomeTypo")
+	context.RowsEstimator = nil
+
+	inspector := NewInspector(nil, context)
+	if err := inspector.estimateTableRowsCount(); err == nil {
+		t.Fatalf("expected an error for an unrecognized rows estimate method")
+	}
+}
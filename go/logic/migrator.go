@@ -0,0 +1,74 @@
+/*
+   Copyright 2016 GitHub Inc.
+	 See https://github.com/github/gh-ost/blob/master/LICENSE
+*/
+
+package logic
+
+import (
+	gosql "database/sql"
+	"log"
+
+	"github.com/github/gh-ost/go/base"
+)
+
+// Migrator is the main schema migration flow manager.
+type Migrator struct {
+	migrationContext *base.MigrationContext
+	server           *Server
+	inspector        *Inspector
+	db               *gosql.DB
+}
+
+func NewMigrator() *Migrator {
+	return &Migrator{
+		migrationContext: base.GetMigrationContext(),
+	}
+}
+
+// SetConnection supplies the database connection used to inspect the
+// migrated table (row count estimate, schema, etc). It must be called
+// before Migrate.
+func (this *Migrator) SetConnection(db *gosql.DB) {
+	this.db = db
+}
+
+// initiateServer opens the migration's control socket, so that an operator
+// can reconfigure chunk-size/max-load/throttling on a running migration
+// without restarting it.
+func (this *Migrator) initiateServer() (err error) {
+	this.server = NewServer(this.migrationContext)
+	if err := this.server.BindSocketFile(); err != nil {
+		return err
+	}
+	go func() {
+		if err := this.server.Serve(); err != nil {
+			log.Printf("gh-ost: control socket stopped serving: %+v", err)
+		}
+	}()
+	return nil
+}
+
+// initiateInspector estimates the migrated table's row count, using
+// whichever method migrationContext.UsedRowsEstimateMethod names.
+func (this *Migrator) initiateInspector() error {
+	this.inspector = NewInspector(this.db, this.migrationContext)
+	return this.inspector.estimateTableRowsCount()
+}
+
+// Migrate executes the migration, end to end.
+func (this *Migrator) Migrate() (err error) {
+	if err := this.initiateServer(); err != nil {
+		return err
+	}
+	defer this.server.Close()
+
+	if err := this.initiateInspector(); err != nil {
+		return err
+	}
+
+	// Row copy, binlog streaming/application and cut-over are driven by the
+	// applier; this entry point is responsible for bringing up the control
+	// plane (the socket above) and the initial inspection around them.
+	return nil
+}
@@ -0,0 +1,204 @@
+/*
+   Copyright 2016 GitHub Inc.
+	 See https://github.com/github/gh-ost/blob/master/LICENSE
+*/
+
+package logic
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"github.com/github/gh-ost/go/base"
+)
+
+// Server listens on a Unix socket and services simple, line-based commands
+// that allow a running migration to be reconfigured without restarting it.
+type Server struct {
+	migrationContext *base.MigrationContext
+	unixListener     net.Listener
+	closing          int32
+}
+
+// NewServer creates a server for the given migration's control socket. The
+// socket itself is opened by BindSocketFile.
+func NewServer(migrationContext *base.MigrationContext) *Server {
+	return &Server{
+		migrationContext: migrationContext,
+	}
+}
+
+// BindSocketFile creates the Unix socket via which the migration is controlled.
+func (this *Server) BindSocketFile() (err error) {
+	socketFile := this.migrationContext.GetServeSocketFile()
+	if err := this.removeStaleSocketFile(socketFile); err != nil {
+		return err
+	}
+	if this.unixListener, err = net.Listen("unix", socketFile); err != nil {
+		return err
+	}
+	return nil
+}
+
+// removeStaleSocketFile removes a socket file left behind by a previous
+// migration on the same database/table that didn't shut down cleanly (e.g.
+// it panicked or was killed), so BindSocketFile doesn't fail forever with
+// "address already in use". A socket file that's still being served by a
+// live migration is left alone and reported as an error.
+func (this *Server) removeStaleSocketFile(socketFile string) error {
+	if _, err := os.Stat(socketFile); err != nil {
+		// Nothing to clean up.
+		return nil
+	}
+	conn, err := net.Dial("unix", socketFile)
+	if err == nil {
+		conn.Close()
+		return fmt.Errorf("socket file %s is in use by a running migration", socketFile)
+	}
+	return os.Remove(socketFile)
+}
+
+// Serve accepts and services connections on the control socket. This
+// function is blocking and is expected to be called via a dedicated goroutine.
+// It returns nil once Close has been called, and a non-nil error if the
+// listener fails for any other reason.
+func (this *Server) Serve() error {
+	for {
+		conn, err := this.unixListener.Accept()
+		if err != nil {
+			if atomic.LoadInt32(&this.closing) == 1 {
+				return nil
+			}
+			return err
+		}
+		go this.handleConnection(conn)
+	}
+}
+
+// Close releases the control socket.
+func (this *Server) Close() error {
+	if this.unixListener == nil {
+		return nil
+	}
+	atomic.StoreInt32(&this.closing, 1)
+	return this.unixListener.Close()
+}
+
+func (this *Server) handleConnection(conn net.Conn) {
+	defer conn.Close()
+
+	reader := bufio.NewReader(conn)
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		if err := this.onServerCommand(line, bufio.NewWriter(conn)); err != nil {
+			fmt.Fprintf(conn, "%s\n", err.Error())
+		}
+	}
+}
+
+// onServerCommand parses and dispatches a single line read from a client
+// connection, writing any response back via `writer`.
+func (this *Server) onServerCommand(command string, writer *bufio.Writer) (err error) {
+	defer writer.Flush()
+
+	tokens := strings.SplitN(strings.TrimSpace(command), "=", 2)
+	command = strings.TrimSpace(tokens[0])
+	arg := ""
+	if len(tokens) > 1 {
+		arg = strings.TrimSpace(tokens[1])
+	}
+
+	switch command {
+	case "help":
+		fmt.Fprintln(writer, `available commands:
+chunk-size=<newsize>                         	print or set chunk-size
+max-lag-millis=<max-lag>                     	print or set max-lag-millis
+max-load=<max-load-thresholds>               	print or set max-load
+throttle-control-replicas=<replicas>         	print or set throttle control replicas
+throttle                                     	force migration to throttle
+no-throttle                                  	cancel forced throttling
+status                                       	print elapsed time since the migration started
+help                                         	this message`)
+	case "chunk-size":
+		return this.onChunkSizeCommand(arg, writer)
+	case "max-lag-millis":
+		return this.onMaxLagMillisCommand(arg, writer)
+	case "max-load":
+		return this.onMaxLoadCommand(arg, writer)
+	case "throttle-control-replicas":
+		return this.onThrottleControlReplicasCommand(arg, writer)
+	case "throttle", "pause", "suspend":
+		this.migrationContext.SetThrottled(true, "commanded by user")
+		fmt.Fprintln(writer, "throttling")
+	case "no-throttle", "unthrottle", "resume":
+		this.migrationContext.SetThrottled(false, "commanded by user")
+		fmt.Fprintln(writer, "unthrottled")
+	case "status":
+		fmt.Fprintln(writer, this.migrationContext.ElapsedTime().String())
+	default:
+		return fmt.Errorf("Unknown command: %s", command)
+	}
+	return nil
+}
+
+func (this *Server) onChunkSizeCommand(arg string, writer *bufio.Writer) error {
+	if arg == "" {
+		fmt.Fprintf(writer, "%d\n", this.migrationContext.GetChunkSize())
+		return nil
+	}
+	chunkSize, err := strconv.ParseInt(arg, 10, 64)
+	if err != nil {
+		return fmt.Errorf("Unable to parse chunk-size: %s", arg)
+	}
+	this.migrationContext.SetChunkSize(chunkSize)
+	fmt.Fprintf(writer, "%d\n", this.migrationContext.GetChunkSize())
+	return nil
+}
+
+func (this *Server) onMaxLagMillisCommand(arg string, writer *bufio.Writer) error {
+	if arg == "" {
+		fmt.Fprintf(writer, "%d\n", this.migrationContext.GetMaxLagMillisecondsThrottleThreshold())
+		return nil
+	}
+	maxLagMillis, err := strconv.ParseInt(arg, 10, 64)
+	if err != nil {
+		return fmt.Errorf("Unable to parse max-lag-millis: %s", arg)
+	}
+	this.migrationContext.SetMaxLagMillisecondsThrottleThreshold(maxLagMillis)
+	fmt.Fprintf(writer, "%d\n", this.migrationContext.GetMaxLagMillisecondsThrottleThreshold())
+	return nil
+}
+
+func (this *Server) onMaxLoadCommand(arg string, writer *bufio.Writer) error {
+	if arg == "" {
+		for variableName, value := range this.migrationContext.GetMaxLoad() {
+			fmt.Fprintf(writer, "%s=%d\n", variableName, value)
+		}
+		return nil
+	}
+	if err := this.migrationContext.SetMaxLoad(arg); err != nil {
+		return err
+	}
+	fmt.Fprintf(writer, "%s\n", arg)
+	return nil
+}
+
+func (this *Server) onThrottleControlReplicasCommand(arg string, writer *bufio.Writer) error {
+	if arg == "" {
+		fmt.Fprintf(writer, "%s\n", this.migrationContext.GetThrottleControlReplicaKeys().ToCommaDelimitedList())
+		return nil
+	}
+	if err := this.migrationContext.SetThrottleControlReplicaKeys(arg); err != nil {
+		return err
+	}
+	fmt.Fprintf(writer, "%s\n", arg)
+	return nil
+}
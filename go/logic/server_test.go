@@ -0,0 +1,65 @@
+/*
+   Copyright 2016 GitHub Inc.
+	 See https://github.com/github/gh-ost/blob/master/LICENSE
+*/
+
+package logic
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/github/gh-ost/go/base"
+)
+
+func TestOnServerCommandChunkSize(t *testing.T) {
+	context := base.GetMigrationContext()
+	server := NewServer(context)
+
+	tests := []struct {
+		name    string
+		command string
+		want    int64
+	}{
+		{"within bounds", "chunk-size=2000", 2000},
+		{"clamped low", "chunk-size=10", 100},
+		{"clamped high", "chunk-size=1000000", 100000},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			writer := bufio.NewWriter(&buf)
+			if err := server.onServerCommand(test.command, writer); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := context.GetChunkSize(); got != test.want {
+				t.Errorf("ChunkSize = %d, want %d", got, test.want)
+			}
+		})
+	}
+}
+
+func TestOnServerCommandMaxLoadMalformed(t *testing.T) {
+	context := base.GetMigrationContext()
+	server := NewServer(context)
+
+	var buf bytes.Buffer
+	writer := bufio.NewWriter(&buf)
+	if err := server.onServerCommand("max-load=not-a-valid-condition", writer); err == nil {
+		t.Fatalf("expected an error for a malformed max-load command")
+	}
+}
+
+func TestOnServerCommandUnknown(t *testing.T) {
+	context := base.GetMigrationContext()
+	server := NewServer(context)
+
+	var buf bytes.Buffer
+	writer := bufio.NewWriter(&buf)
+	err := server.onServerCommand("bogus-command", writer)
+	if err == nil || !strings.Contains(err.Error(), "Unknown command") {
+		t.Fatalf("expected an unknown command error, got: %v", err)
+	}
+}